@@ -3,9 +3,13 @@ package bun
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 
+	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/schema"
@@ -16,11 +20,19 @@ type InsertQuery struct {
 	returningQuery
 	customValueQuery
 
-	onConflict schema.QueryWithArgs
+	onConflict              schema.QueryWithArgs
+	conflictColumns         []string
+	conflictDoNothingTarget []string
 	setQuery
 
 	ignore  bool
 	replace bool
+
+	batchSize    int
+	copyFrom     bool
+	ensureSchema bool
+
+	from schema.QueryAppender
 }
 
 func NewInsertQuery(db *DB) *InsertQuery {
@@ -76,6 +88,22 @@ func (q *InsertQuery) ModelTableExpr(query string, args ...interface{}) *InsertQ
 
 //------------------------------------------------------------------------------
 
+// From turns the query into an `INSERT INTO table (columns...) SELECT ...`
+// statement, taking the column list from sq's selected columns. Unlike
+// Table/TableExpr, sq does not need to be registered as a bun Table.
+func (q *InsertQuery) From(sq *SelectQuery) *InsertQuery {
+	q.from = sq
+	return q
+}
+
+// FromExpr is like From but accepts a raw SELECT query.
+func (q *InsertQuery) FromExpr(query string, args ...interface{}) *InsertQuery {
+	q.from = schema.SafeQuery(query, args)
+	return q
+}
+
+//------------------------------------------------------------------------------
+
 func (q *InsertQuery) Column(columns ...string) *InsertQuery {
 	for _, column := range columns {
 		q.addColumn(schema.UnsafeIdent(column))
@@ -141,6 +169,257 @@ func (q *InsertQuery) Replace() *InsertQuery {
 
 //------------------------------------------------------------------------------
 
+// Batch splits a `*[]T` slice model into multiple INSERT statements of at
+// most size rows each, so that a single Exec call never builds a statement
+// with more parameters than the driver/protocol allows. Batches run
+// sequentially over the query's connection (so they share a transaction when
+// one is active); their sql.Result.RowsAffected are summed and LastInsertId
+// reports the first batch's value, matching how a single multi-row INSERT
+// reports it. Returning is honored per batch, scanning each batch's rows
+// into its own slice elements; an explicit dest argument is not supported
+// together with Batch.
+func (q *InsertQuery) Batch(size int) *InsertQuery {
+	q.batchSize = size
+	return q
+}
+
+//------------------------------------------------------------------------------
+
+// EnsureSchema opts this insert into a one-time auto-migrate retry: if Exec
+// fails with an "undefined column"/"no such table"-shaped error, it
+// consults the registered schema.Table, issues the minimal
+// CREATE TABLE IF NOT EXISTS / ALTER TABLE ADD COLUMN statements needed to
+// reconcile the database with the model (see ensureTableColumns for how
+// existing columns are skipped on each dialect), logs the DDL
+// it ran via internal.Logger, and retries the insert once. It is off by
+// default and per-query; a DB wide default would hang a flag off DB the
+// same way WithDiscardUnknownColumns does.
+func (q *InsertQuery) EnsureSchema() *InsertQuery {
+	q.ensureSchema = true
+	return q
+}
+
+// isMissingSchemaErr reports whether err looks like the "table"/"column
+// doesn't exist" error each dialect's driver returns, using the wording that
+// dialect actually produces rather than matching across all of them.
+func isMissingSchemaErr(name dialect.Name, err error) bool {
+	msg := strings.ToLower(err.Error())
+	switch name {
+	case dialect.PG:
+		return strings.Contains(msg, "does not exist")
+	case dialect.MySQL:
+		return strings.Contains(msg, "doesn't exist") || strings.Contains(msg, "unknown column")
+	case dialect.SQLite:
+		return strings.Contains(msg, "no such table") || strings.Contains(msg, "no such column")
+	default:
+		return false
+	}
+}
+
+// ensureTableSchema issues the DDL needed to make the database match
+// q.table: a CREATE TABLE IF NOT EXISTS covering every field, which is valid
+// on every dialect and handles the common "no such table" case everywhere,
+// followed by an ALTER TABLE ADD COLUMN for every field the table is
+// missing. Field names and types both come from q.table, so every
+// identifier here is already dialect-quoted (schema.Table.quoteIdent ran
+// when the table was registered against q.db's dialect).
+func (q *InsertQuery) ensureTableSchema(ctx context.Context) error {
+	b := make([]byte, 0, 64)
+	b = append(b, "CREATE TABLE IF NOT EXISTS "...)
+	b = append(b, q.table.SQLName...)
+	b = append(b, " ("...)
+	for i, f := range q.table.Fields {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, f.SQLName...)
+		b = append(b, ' ')
+		b = append(b, f.CreateTableSQLType...)
+	}
+	b = append(b, ')')
+
+	if err := q.ensureSchemaExec(ctx, internal.String(b)); err != nil {
+		return err
+	}
+
+	return q.ensureTableColumns(ctx)
+}
+
+// ensureTableColumns adds whatever fields q.table has that the table
+// doesn't. On Postgres, ALTER TABLE ADD COLUMN IF NOT EXISTS is valid SQL
+// and genuinely minimal (a no-op when the column is already there), so it
+// needs no introspection. MySQL and SQLite have no such guard - running
+// ADD COLUMN for a column that already exists is an error there - so on
+// those two dialects the table's current columns are read first (a
+// SELECT ... LIMIT 0, portable across both) and only the columns actually
+// missing get ALTER TABLE ADD COLUMN.
+func (q *InsertQuery) ensureTableColumns(ctx context.Context) error {
+	if q.db.dialect.Name() == dialect.PG {
+		for _, f := range q.table.Fields {
+			alter := fmt.Sprintf(
+				"ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s",
+				q.table.SQLName, f.SQLName, f.CreateTableSQLType,
+			)
+			if err := q.ensureSchemaExec(ctx, alter); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	existing, err := q.existingColumns(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range q.table.Fields {
+		if existing[strings.ToLower(f.Name)] {
+			continue
+		}
+		alter := fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN %s %s",
+			q.table.SQLName, f.SQLName, f.CreateTableSQLType,
+		)
+		if err := q.ensureSchemaExec(ctx, alter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// existingColumns reports the table's current column names, lowercased.
+func (q *InsertQuery) existingColumns(ctx context.Context) (map[string]bool, error) {
+	rows, err := q.conn.QueryContext(ctx, "SELECT * FROM "+string(q.table.SQLName)+" LIMIT 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		existing[strings.ToLower(c)] = true
+	}
+	return existing, nil
+}
+
+func (q *InsertQuery) ensureSchemaExec(ctx context.Context, query string) error {
+	internal.Logger.Printf("bun: ensuring schema: %s", query)
+	_, err := q.conn.ExecContext(ctx, query)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// Copy makes Exec stream the slice model into the table via the Postgres
+// COPY FROM STDIN protocol instead of a multi-row INSERT. It only applies
+// on Postgres (dialect.PG) and only when the underlying connection
+// implements copyFromer; bun's own pgdriver connection does not speak the
+// COPY sub-protocol (a bare PrepareContext("COPY ... FROM STDIN") is not
+// enough - no driver recognizes that as special, it just fails as an
+// ordinary, invalid prepared statement) and this package does not implement
+// it either, so Copy is only useful wrapped around a connection that adds
+// it (e.g. a thin copyFromer shim over a pgx/lib-pq CopyFrom call). On every
+// other connection Copy falls back to the regular (possibly batched) INSERT
+// VALUES path. Returning and insert hooks are not supported in COPY mode.
+func (q *InsertQuery) Copy() *InsertQuery {
+	q.copyFrom = true
+	return q
+}
+
+// copyFromer is implemented by connections that natively support the
+// Postgres COPY FROM STDIN protocol (CopyInResponse/CopyData/CopyDone), not
+// merely ones that can prepare statements - every *sql.DB/*sql.Conn/*sql.Tx
+// can do that, which is not the same thing and is not enough to make COPY
+// work. It is checked with a type assertion rather than added to IConn so
+// that Copy can fall back cleanly on connections that don't implement it.
+// rows writes COPY TEXT format (see appendCopyValue) to w; a CopyFrom
+// implementation forwards that unmodified as CopyData payload(s).
+type copyFromer interface {
+	CopyFrom(ctx context.Context, query string, rows func(w io.Writer) error) (sql.Result, error)
+}
+
+// execCopyFrom streams model's rows into table via COPY FROM STDIN. The
+// column list is taken from getFields, the same column set a regular
+// INSERT would use. Rows are encoded in Postgres COPY TEXT format (tab
+// separated, \N for NULL, backslash-escaped tab/newline/backslash), the
+// format CopyFrom implementations are expected to write to the server as-is.
+func (q *InsertQuery) execCopyFrom(
+	ctx context.Context, dest []interface{}, model *sliceTableModel,
+) (sql.Result, error) {
+	copier, ok := q.conn.(copyFromer)
+	if !ok {
+		return q.execOrBatch(ctx, dest)
+	}
+
+	fields, err := q.getFields()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+
+	query := "COPY " + string(q.table.SQLName) +
+		" (" + strings.Join(columns, ", ") + ") FROM STDIN"
+
+	return copier.CopyFrom(ctx, query, func(w io.Writer) error {
+		row := make([]byte, 0, 64)
+		for i := 0; i < model.sliceLen; i++ {
+			strct := indirect(model.slice.Index(i))
+			row = row[:0]
+			for j, f := range fields {
+				if j > 0 {
+					row = append(row, '\t')
+				}
+				row = appendCopyValue(row, f, strct)
+			}
+			row = append(row, '\n')
+			if _, err := w.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// appendCopyValue appends f's value from strct to b in Postgres COPY TEXT
+// format: a NullZero field holding its zero value is the literal `\N`
+// (COPY's NULL marker), and backslash/tab/newline/carriage-return are
+// backslash-escaped so a value's contents can never be mistaken for the
+// column or row delimiter.
+func appendCopyValue(b []byte, f *schema.Field, strct reflect.Value) []byte {
+	if f.NullZero && f.HasZeroValue(strct) {
+		return append(b, `\N`...)
+	}
+
+	s := fmt.Sprint(f.Value(strct).Interface())
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b = append(b, '\\', '\\')
+		case '\t':
+			b = append(b, '\\', 't')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		default:
+			b = append(b, string(r)...)
+		}
+	}
+	return b
+}
+
+//------------------------------------------------------------------------------
+
 func (q *InsertQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	if q.err != nil {
 		return nil, q.err
@@ -194,6 +473,10 @@ func (q *InsertQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, e
 func (q *InsertQuery) appendColumnsValues(
 	fmter schema.Formatter, b []byte,
 ) (_ []byte, err error) {
+	if q.from != nil {
+		return q.appendColumnsFrom(fmter, b)
+	}
+
 	if q.hasMultiTables() {
 		if q.columns != nil {
 			b = append(b, " ("...)
@@ -253,6 +536,42 @@ func (q *InsertQuery) appendColumnsValues(
 	return b, nil
 }
 
+func (q *InsertQuery) appendColumnsFrom(fmter schema.Formatter, b []byte) (_ []byte, err error) {
+	columns := q.columns
+	if len(columns) == 0 {
+		if sq, ok := q.from.(*SelectQuery); ok {
+			columns = sq.columns
+		}
+	}
+
+	if len(columns) > 0 {
+		b = append(b, " ("...)
+		b, err = appendColumnList(fmter, b, columns)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, ")"...)
+	}
+
+	b = append(b, " "...)
+	return q.from.AppendQuery(fmter, b)
+}
+
+func appendColumnList(
+	fmter schema.Formatter, b []byte, columns []schema.QueryWithArgs,
+) (_ []byte, err error) {
+	for i, c := range columns {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b, err = c.AppendQuery(fmter, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
 func (q *InsertQuery) appendStructValues(
 	fmter schema.Formatter, b []byte, fields []*schema.Field, strct reflect.Value,
 ) (_ []byte, err error) {
@@ -391,6 +710,115 @@ func (q *InsertQuery) Set(query string, args ...interface{}) *InsertQuery {
 	return q
 }
 
+//------------------------------------------------------------------------------
+
+// OnConflict starts a structured upsert clause for the columns of the
+// unique index/constraint that may conflict (use OnConstraint for a named
+// constraint instead). Finish it with DoNothing, DoUpdateAll, or
+// DoUpdateColumns, optionally followed by Set and Where. It compiles to
+// Postgres/SQLite `ON CONFLICT ... DO ...` or MySQL
+// `ON DUPLICATE KEY UPDATE`/`INSERT IGNORE`, picked via feature.OnDuplicateKey,
+// so the same builder works across dialects without On/Set string literals.
+func (q *InsertQuery) OnConflict(target ...string) *ConflictClause {
+	return &ConflictClause{q: q, target: target}
+}
+
+// ConflictClause is returned by InsertQuery.OnConflict.
+type ConflictClause struct {
+	q *InsertQuery
+
+	target     []string
+	constraint string
+}
+
+// OnConstraint targets a named constraint instead of a column list.
+// Ignored on MySQL, which has no equivalent.
+func (c *ConflictClause) OnConstraint(name string) *ConflictClause {
+	c.constraint = name
+	return c
+}
+
+// DoNothing makes conflicting rows a no-op.
+//
+// Combined with Returning into a slice model, a conflicting row returns no
+// RETURNING row at all. When the conflict target was given as an explicit
+// column list (OnConflict("col", ...), not OnConstraint), bun matches
+// returned rows back to the slice element they came from by those columns,
+// so a skipped row keeps the values the caller set instead of being
+// silently overwritten by whichever row scans into its old position.
+// The match reads the target columns from the returned rows, so they must be
+// among the RETURNING columns: a returned row whose target values match no
+// element is discarded, and the automatic RETURNING of server-generated
+// columns alone (without the target columns) updates no element at all.
+// OnConstraint has no column list to match on, so that combination falls
+// back to the plain position-based scan and can misalign under DO NOTHING.
+func (c *ConflictClause) DoNothing() *ConflictClause {
+	if c.q.db.features.Has(feature.OnDuplicateKey) {
+		c.q.ignore = true
+		return c
+	}
+	if len(c.target) > 0 {
+		c.q.conflictDoNothingTarget = c.target
+	}
+	c.q.On(c.conflictTarget() + "DO NOTHING")
+	return c
+}
+
+// DoUpdateAll updates every data column from the row that conflicted.
+func (c *ConflictClause) DoUpdateAll() *ConflictClause {
+	c.doUpdate()
+	return c
+}
+
+// DoUpdateColumns is like DoUpdateAll but restricted to the given columns.
+// Unlike Column, it only narrows the UPDATE branch's SET list and leaves the
+// INSERT's own column list untouched.
+func (c *ConflictClause) DoUpdateColumns(columns ...string) *ConflictClause {
+	c.q.conflictColumns = columns
+	c.doUpdate()
+	return c
+}
+
+func (c *ConflictClause) doUpdate() {
+	if c.q.db.features.Has(feature.OnDuplicateKey) {
+		c.q.On("DUPLICATE KEY UPDATE")
+		return
+	}
+	c.q.On(c.conflictTarget() + "DO UPDATE")
+}
+
+// Set adds a SET expression to the UPDATE branch, e.g. to merge values
+// instead of overwriting them outright.
+func (c *ConflictClause) Set(query string, args ...interface{}) *ConflictClause {
+	c.q.Set(query, args...)
+	return c
+}
+
+// Where adds a WHERE condition to the UPDATE branch (Postgres/SQLite only).
+func (c *ConflictClause) Where(query string, args ...interface{}) *ConflictClause {
+	c.q.Where(query, args...)
+	return c
+}
+
+// onConflictDoUpdate reports whether the ON clause ends in an UPDATE branch,
+// i.e. was built by ConflictClause.doUpdate (DoUpdateAll/DoUpdateColumns)
+// rather than DoNothing.
+func (q *InsertQuery) onConflictDoUpdate() bool {
+	query := strings.ToUpper(q.onConflict.Query)
+	return strings.HasSuffix(query, " DO UPDATE") || strings.HasSuffix(query, "DUPLICATE KEY UPDATE")
+}
+
+func (c *ConflictClause) conflictTarget() string {
+	switch {
+	case c.constraint != "":
+		return "CONFLICT ON CONSTRAINT " + c.constraint + " "
+	case len(c.target) > 0:
+		return "CONFLICT (" + strings.Join(c.target, ", ") + ") "
+	default:
+		return "CONFLICT "
+	}
+}
+
 func (q *InsertQuery) appendOn(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	if q.onConflict.IsZero() {
 		return b, nil
@@ -413,17 +841,24 @@ func (q *InsertQuery) appendOn(fmter schema.Formatter, b []byte) (_ []byte, err
 		if err != nil {
 			return nil, err
 		}
-	} else if len(q.columns) > 0 {
-		fields, err := q.getDataFields()
-		if err != nil {
-			return nil, err
+	} else if q.onConflictDoUpdate() {
+		fields := q.tableModel.Table().DataFields
+		if len(q.conflictColumns) > 0 {
+			fields = make([]*schema.Field, 0, len(q.conflictColumns))
+			for _, name := range q.conflictColumns {
+				field, err := q.table.Field(name)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, field)
+			}
 		}
 
-		if len(fields) == 0 {
-			fields = q.tableModel.Table().DataFields
+		if fmter.HasFeature(feature.OnDuplicateKey) {
+			b = q.appendSetValues(b, fields)
+		} else {
+			b = q.appendSetExcluded(b, fields)
 		}
-
-		b = q.appendSetExcluded(b, fields)
 	}
 
 	b, err = q.appendWhere(fmter, b, true)
@@ -447,6 +882,22 @@ func (q *InsertQuery) appendSetExcluded(b []byte, fields []*schema.Field) []byte
 	return b
 }
 
+// appendSetValues appends a MySQL `SET col = VALUES(col)` clause, the
+// ON DUPLICATE KEY UPDATE equivalent of appendSetExcluded's EXCLUDED.col.
+func (q *InsertQuery) appendSetValues(b []byte, fields []*schema.Field) []byte {
+	b = append(b, " "...)
+	for i, f := range fields {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, f.SQLName...)
+		b = append(b, " = VALUES("...)
+		b = append(b, f.SQLName...)
+		b = append(b, ")"...)
+	}
+	return b
+}
+
 //------------------------------------------------------------------------------
 
 func (q *InsertQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result, error) {
@@ -456,15 +907,77 @@ func (q *InsertQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result
 		}
 	}
 
+	var res sql.Result
+	var err error
+
+	if model, ok := q.tableModel.(*sliceTableModel); ok &&
+		q.copyFrom && q.db.dialect.Name() == dialect.PG {
+		res, err = q.execCopyFrom(ctx, dest, model)
+	} else {
+		res, err = q.execOrBatch(ctx, dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if q.table != nil {
+		if err := q.afterInsertHook(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// execOrBatch runs a regular INSERT, splitting it into multiple statements
+// via execBatches when Batch was used on a large enough slice model. The
+// explicit-dest rejection lives here rather than in execBatches so it fires
+// whenever Batch was configured, not only once a slice is actually large
+// enough to need splitting.
+func (q *InsertQuery) execOrBatch(ctx context.Context, dest []interface{}) (sql.Result, error) {
+	if q.batchSize > 0 && len(dest) > 0 {
+		return nil, fmt.Errorf("bun: Batch does not support an explicit dest argument")
+	}
+
+	if model, ok := q.tableModel.(*sliceTableModel); ok &&
+		q.batchSize > 0 && model.sliceLen > q.batchSize {
+		return q.execBatches(ctx, dest, model)
+	}
+	return q.exec1(ctx, dest)
+}
+
+// exec1 builds and runs a single INSERT statement for the query's current
+// table model. It is also what each batch produced by Batch runs in turn.
+// When EnsureSchema was used and the first attempt fails with a missing
+// table/column error, it migrates the schema and retries once.
+func (q *InsertQuery) exec1(ctx context.Context, dest []interface{}) (sql.Result, error) {
+	res, err := q.exec1Once(ctx, dest)
+	if err != nil && q.ensureSchema && q.table != nil && isMissingSchemaErr(q.db.dialect.Name(), err) {
+		if migErr := q.ensureTableSchema(ctx); migErr != nil {
+			return nil, err
+		}
+		return q.exec1Once(ctx, dest)
+	}
+	return res, err
+}
+
+func (q *InsertQuery) exec1Once(ctx context.Context, dest []interface{}) (sql.Result, error) {
 	queryBytes, err := q.AppendQuery(q.db.fmter, q.db.makeQueryBytes())
 	if err != nil {
 		return nil, err
 	}
 
 	query := internal.String(queryBytes)
+	hasDest := len(dest) > 0
 	var res sql.Result
 
-	if hasDest := len(dest) > 0; hasDest || q.hasReturning() {
+	if !hasDest && q.hasReturning() && len(q.conflictDoNothingTarget) > 0 && q.from == nil {
+		if model, ok := q.tableModel.(*sliceTableModel); ok {
+			return q.execReturningDoNothing(ctx, query, model)
+		}
+	}
+
+	if hasDest || q.hasReturning() {
 		model, err := q.getModel(dest)
 		if err != nil {
 			return nil, err
@@ -485,15 +998,149 @@ func (q *InsertQuery) Exec(ctx context.Context, dest ...interface{}) (sql.Result
 		}
 	}
 
-	if q.table != nil {
-		if err := q.afterInsertHook(ctx); err != nil {
+	return res, nil
+}
+
+// execReturningDoNothing runs a RETURNING insert whose ON CONFLICT clause is
+// DoNothing, matching each returned row back to the slice element it came
+// from by the conflict target columns instead of row position. A row that
+// conflicted returns no row at all, so the position-based scan that q.scan
+// uses for every other insert would shift every element after a skip into
+// the wrong slot; this leaves a skipped element exactly as the caller set
+// it instead of corrupting it with a later row's data.
+func (q *InsertQuery) execReturningDoNothing(
+	ctx context.Context, query string, model *sliceTableModel,
+) (sql.Result, error) {
+	targetFields := make([]*schema.Field, len(q.conflictDoNothingTarget))
+	for i, name := range q.conflictDoNothingTarget {
+		field, err := q.table.Field(name)
+		if err != nil {
+			return nil, err
+		}
+		targetFields[i] = field
+	}
+
+	byTarget := make(map[string]reflect.Value, model.sliceLen)
+	for i := 0; i < model.sliceLen; i++ {
+		strct := indirect(model.slice.Index(i))
+		byTarget[conflictTargetKey(targetFields, strct)] = strct
+	}
+
+	ctx, event := q.db.beforeQuery(ctx, q, query, nil)
+
+	rows, err := q.conn.QueryContext(ctx, query)
+	if err != nil {
+		q.db.afterQuery(ctx, event, nil, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		q.db.afterQuery(ctx, event, nil, err)
+		return nil, err
+	}
+	model.columns = columns
+	dest := makeDest(model, len(columns))
+	scratch := reflect.New(q.table.Type).Elem()
+
+	var n int
+	for rows.Next() {
+		model.strct = scratch
+		model.structInited = false
+
+		if err := model.scanRow(ctx, rows, dest); err != nil {
+			q.db.afterQuery(ctx, event, nil, err)
 			return nil, err
 		}
+
+		if target, ok := byTarget[conflictTargetKey(targetFields, scratch)]; ok {
+			target.Set(scratch)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		q.db.afterQuery(ctx, event, nil, err)
+		return nil, err
 	}
 
+	res := driver.RowsAffected(n)
+	q.db.afterQuery(ctx, event, res, nil)
 	return res, nil
 }
 
+// conflictTargetKey builds a lookup key from a struct's conflict target
+// column values, used to match a RETURNING row back to the slice element it
+// was inserted from.
+func conflictTargetKey(fields []*schema.Field, strct reflect.Value) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = fmt.Sprint(f.Value(strct).Interface())
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// execBatches runs model's rows through exec1 in chunks of q.batchSize,
+// summing RowsAffected and carrying forward LastInsertId across batches.
+// Each batch shares q's connection, so batches participate in the caller's
+// transaction when one is active. dest is always empty here: execOrBatch
+// rejects an explicit dest argument before a Batch-configured query ever
+// reaches this function, since dest is scanned by scanModel, which reads a
+// single row regardless of how many rows the batch inserted, so splitting
+// into batches would silently overwrite dest with whichever batch ran last
+// instead of reporting every row. Returning into the slice model itself
+// (the no-dest path) is unaffected and works per batch.
+func (q *InsertQuery) execBatches(
+	ctx context.Context, dest []interface{}, model *sliceTableModel,
+) (sql.Result, error) {
+	var affected int64
+	var lastInsertID int64
+	var haveLastInsertID bool
+
+	for start := 0; start < model.sliceLen; start += q.batchSize {
+		end := start + q.batchSize
+		if end > model.sliceLen {
+			end = model.sliceLen
+		}
+
+		// The sub-slice needs its own addressable reflect.Value (copying model.slice's
+		// header isn't enough: ScanRows truncates and re-grows it via Set, which
+		// requires CanSet) and its own nextElem bound to that sub-slice, or Returning
+		// would grow the original model's full slice instead of this batch's rows.
+		sub := reflect.New(model.slice.Type()).Elem()
+		sub.Set(model.slice.Slice(start, end))
+
+		batchModel := *model
+		batchModel.slice = sub
+		batchModel.sliceLen = end - start
+		batchModel.nextElem = makeSliceNextElemFunc(sub)
+
+		batch := *q
+		batch.tableModel = &batchModel
+		batch.model = &batchModel
+
+		res, err := batch.exec1(ctx, dest)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			affected += n
+		}
+		if !haveLastInsertID {
+			if id, err := res.LastInsertId(); err == nil {
+				lastInsertID = id
+				haveLastInsertID = true
+			}
+		}
+	}
+
+	return driverResult{
+		affected:        affected,
+		lastInsertID:    lastInsertID,
+		hasLastInsertID: haveLastInsertID,
+	}, nil
+}
+
 func (q *InsertQuery) beforeInsertHook(ctx context.Context) error {
 	if hook, ok := q.table.ZeroIface.(BeforeInsertHook); ok {
 		if err := hook.BeforeInsert(ctx, q); err != nil {
@@ -549,3 +1196,24 @@ func (q *InsertQuery) tryLastInsertID(res sql.Result, dest []interface{}) error
 
 	return nil
 }
+
+//------------------------------------------------------------------------------
+
+// driverResult is the sql.Result that execBatches returns: the summed
+// RowsAffected of every batch and the first batch's LastInsertId, if any.
+type driverResult struct {
+	affected        int64
+	lastInsertID    int64
+	hasLastInsertID bool
+}
+
+func (r driverResult) LastInsertId() (int64, error) {
+	if !r.hasLastInsertID {
+		return 0, fmt.Errorf("bun: LastInsertId is not supported for this query")
+	}
+	return r.lastInsertID, nil
+}
+
+func (r driverResult) RowsAffected() (int64, error) {
+	return r.affected, nil
+}
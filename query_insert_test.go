@@ -0,0 +1,468 @@
+package bun
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+//------------------------------------------------------------------------------
+// A minimal database/sql/driver.Driver that records every query it is asked
+// to run and lets a test script its results, so InsertQuery.Exec can be
+// driven end-to-end without a real database.
+
+type fakeDriver struct {
+	mu      sync.Mutex
+	queries []string
+	errs    map[int]error
+
+	// queryRows, when set, scripts the rows QueryContext returns instead of
+	// the default single-column version probe response.
+	queryRows func(query string) *fakeRows
+}
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDriver) {
+	t.Helper()
+
+	drv := &fakeDriver{}
+	name := fmt.Sprintf("bun-fake-%p", drv)
+	sql.Register(name, drv)
+
+	sqldb, err := sql.Open(name, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { sqldb.Close() })
+
+	return sqldb, drv
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) record(query string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, query)
+	return len(d.queries) - 1
+}
+
+func (d *fakeDriver) errFor(idx int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.errs[idx]
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c: c, query: query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	idx := c.d.record(query)
+	if err := c.d.errFor(idx); err != nil {
+		return nil, err
+	}
+
+	// Bun inlines row values into the query text, so the number of
+	// value-tuples (and therefore rows affected) can be read back out of it.
+	rows := int64(1)
+	if i := strings.Index(strings.ToUpper(query), "VALUES ("); i >= 0 {
+		rows += int64(strings.Count(query[i:], "), ("))
+	}
+
+	return fakeResult{lastInsertID: int64(idx + 1), rowsAffected: rows}, nil
+}
+
+func (c *fakeConn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.d.record(query)
+	if c.d.queryRows != nil {
+		return c.d.queryRows(query), nil
+	}
+	return &fakeRows{columns: []string{"version"}, rows: [][]driver.Value{{"13.0"}}}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	idx := s.c.d.record(s.query)
+	return fakeResult{lastInsertID: int64(idx + 1), rowsAffected: 1}, nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.record(s.query)
+	return &fakeRows{columns: []string{"version"}, rows: [][]driver.Value{{"13.0"}}}, nil
+}
+
+type fakeResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	i       int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type insertTestModel struct {
+	ID   int64  `bun:"id,pk,autoincrement"`
+	Name string `bun:"name"`
+}
+
+func TestIsMissingSchemaErr(t *testing.T) {
+	tests := []struct {
+		name string
+		d    dialect.Name
+		msg  string
+		want bool
+	}{
+		{"pg missing column", dialect.PG, `column "foo" of relation "bar" does not exist`, true},
+		{"pg missing table", dialect.PG, `relation "bar" does not exist`, true},
+		{"pg unrelated", dialect.PG, "duplicate key value violates unique constraint", false},
+		{"mysql missing table", dialect.MySQL, "Error 1146: Table 'x.bar' doesn't exist", true},
+		{"mysql missing column", dialect.MySQL, "Error 1054: Unknown column 'foo' in 'field list'", true},
+		{"mysql unrelated", dialect.MySQL, "Error 1062: Duplicate entry", false},
+		{"sqlite missing table", dialect.SQLite, "no such table: bar", true},
+		{"sqlite missing column", dialect.SQLite, "no such column: foo", true},
+		{"sqlite unrelated", dialect.SQLite, "UNIQUE constraint failed: bar.id", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isMissingSchemaErr(tt.d, fmt.Errorf(tt.msg))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConflictClauseDoUpdateAllPostgres(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{{ID: 1, Name: "a"}}
+	q := NewInsertQuery(db).Model(&rows)
+	q.OnConflict("id").DoUpdateAll()
+
+	b, err := q.AppendQuery(db.fmter, nil)
+	require.NoError(t, err)
+	query := string(b)
+
+	assert.Contains(t, query, "ON CONFLICT (id) DO UPDATE SET")
+	assert.Contains(t, query, `"name" = EXCLUDED."name"`)
+}
+
+func TestConflictClauseDoUpdateAllMySQL(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, mysqldialect.New())
+
+	rows := []insertTestModel{{ID: 1, Name: "a"}}
+	q := NewInsertQuery(db).Model(&rows)
+	q.OnConflict().DoUpdateAll()
+
+	b, err := q.AppendQuery(db.fmter, nil)
+	require.NoError(t, err)
+	query := string(b)
+
+	assert.Contains(t, query, "ON DUPLICATE KEY UPDATE")
+	assert.Contains(t, query, "`name` = VALUES(`name`)")
+}
+
+func TestConflictClauseDoUpdateColumns(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{{ID: 1, Name: "a"}}
+	q := NewInsertQuery(db).Model(&rows)
+	q.OnConflict("id").DoUpdateColumns("name")
+
+	b, err := q.AppendQuery(db.fmter, nil)
+	require.NoError(t, err)
+	query := string(b)
+
+	// The INSERT's own column list must still cover every column, not just
+	// the ones named in DoUpdateColumns.
+	assert.Contains(t, query, `("id", "name") VALUES`)
+	assert.Contains(t, query, "ON CONFLICT (id) DO UPDATE SET")
+	assert.Contains(t, query, `"name" = EXCLUDED."name"`)
+}
+
+func TestInsertOnConflictDoNothingMatchesReturningByTarget(t *testing.T) {
+	sqldb, drv := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{
+		{Name: "a"}, {Name: "b"}, {Name: "c"},
+	}
+	q := NewInsertQuery(db).Model(&rows).Returning("id, name")
+	q.OnConflict("name").DoNothing()
+
+	// "b" conflicted and was skipped, so only "a" and "c" come back.
+	drv.queryRows = func(query string) *fakeRows {
+		return &fakeRows{
+			columns: []string{"id", "name"},
+			rows: [][]driver.Value{
+				{int64(101), "a"},
+				{int64(103), "c"},
+			},
+		}
+	}
+
+	_, err := q.Exec(context.Background())
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 101, rows[0].ID)
+	assert.EqualValues(t, 0, rows[1].ID, "row b conflicted and was skipped, so it keeps its original id")
+	assert.EqualValues(t, 103, rows[2].ID)
+}
+
+func TestInsertFromSelect(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	sq := NewSelectQuery(db).Model((*insertTestModel)(nil)).Column("id", "name").Table("other")
+	q := NewInsertQuery(db).Model((*insertTestModel)(nil)).From(sq)
+
+	b, err := q.AppendQuery(db.fmter, nil)
+	require.NoError(t, err)
+	query := string(b)
+
+	assert.Contains(t, query, "INSERT INTO")
+	assert.Contains(t, query, "SELECT")
+	assert.Contains(t, query, `"other"`)
+}
+
+func TestInsertBatchSplitsAndSumsRowsAffected(t *testing.T) {
+	sqldb, drv := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{
+		{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}, {ID: 4, Name: "d"}, {ID: 5, Name: "e"},
+	}
+	q := NewInsertQuery(db).Model(&rows).Batch(2)
+
+	res, err := q.Exec(context.Background())
+	require.NoError(t, err)
+
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, affected)
+
+	// Batches of 2,2,1 rows over 5 rows means three INSERT statements.
+	assert.Len(t, drv.queries, 3)
+
+	// LastInsertId carries forward the first batch's id rather than erroring.
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+}
+
+func TestInsertBatchRejectsExplicitDest(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	q := NewInsertQuery(db).Model(&rows).Batch(1)
+
+	var dest []int64
+	_, err := q.Exec(context.Background(), &dest)
+	assert.Error(t, err)
+}
+
+// A slice small enough to fit in a single batch never reaches execBatches,
+// so the explicit-dest rejection must also be enforced earlier, in
+// execOrBatch, or it would be silently skipped here.
+func TestInsertBatchRejectsExplicitDestWithoutSplitting(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{{Name: "a"}, {Name: "b"}}
+	q := NewInsertQuery(db).Model(&rows).Batch(5)
+
+	var dest []int64
+	_, err := q.Exec(context.Background(), &dest)
+	assert.Error(t, err)
+}
+
+func TestInsertBatchReturningDoesNotLeakAcrossBatches(t *testing.T) {
+	sqldb, drv := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	// Zero IDs trigger bun's automatic RETURNING on the autoincrement PK.
+	rows := []insertTestModel{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+	q := NewInsertQuery(db).Model(&rows).Batch(2)
+
+	var nextID int64 = 10
+	drv.queryRows = func(query string) *fakeRows {
+		n := int64(1)
+		if i := strings.Index(strings.ToUpper(query), "VALUES ("); i >= 0 {
+			n += int64(strings.Count(query[i:], "), ("))
+		}
+		result := &fakeRows{columns: []string{"id"}}
+		for i := int64(0); i < n; i++ {
+			nextID++
+			result.rows = append(result.rows, []driver.Value{nextID})
+		}
+		return result
+	}
+
+	_, err := q.Exec(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, drv.queries, 2)
+	for _, query := range drv.queries {
+		i := strings.Index(query, "RETURNING")
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, 1, strings.Count(query[i:], `"id"`),
+			"each batch's RETURNING clause must list id once, not accumulate across batches")
+	}
+
+	for _, r := range rows {
+		assert.NotZero(t, r.ID)
+	}
+}
+
+func TestCopyFallsBackWithoutCopyFromer(t *testing.T) {
+	sqldb, drv := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	rows := []insertTestModel{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+	q := NewInsertQuery(db).Model(&rows).Copy()
+
+	_, err := q.Exec(context.Background())
+	require.NoError(t, err)
+
+	for _, query := range drv.queries {
+		assert.NotContains(t, strings.ToUpper(query), "COPY")
+	}
+}
+
+// copyCapableConn implements copyFromer on top of the fake *sql.DB so Copy
+// can be driven end-to-end without a real COPY-speaking driver.
+type copyCapableConn struct {
+	*sql.DB
+	rows [][]byte
+}
+
+func (c *copyCapableConn) CopyFrom(
+	ctx context.Context, query string, rows func(w io.Writer) error,
+) (sql.Result, error) {
+	var buf bytes.Buffer
+	if err := rows(&buf); err != nil {
+		return nil, err
+	}
+	c.rows = append(c.rows, buf.Bytes())
+	return fakeResult{rowsAffected: 1}, nil
+}
+
+func TestCopyEncodesNullAndEscapesSpecialBytes(t *testing.T) {
+	sqldb, _ := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+	conn := &copyCapableConn{DB: sqldb}
+
+	rows := []insertTestModel{
+		{ID: 1, Name: "a\tb\nc\\d"},
+		{Name: "e"}, // zero ID -> NullZero PK -> COPY NULL marker
+	}
+	q := NewInsertQuery(db).Conn(conn).Model(&rows).Copy()
+
+	_, err := q.Exec(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, conn.rows, 1)
+	assert.Equal(t, "1\ta\\tb\\nc\\\\d\n\\N\te\n", string(conn.rows[0]))
+}
+
+func TestEnsureSchemaDDLIsDialectGated(t *testing.T) {
+	sqldb, drv := newFakeDB(t)
+	db := NewDB(sqldb, pgdialect.New())
+
+	model := &insertTestModel{Name: "a"}
+	q := NewInsertQuery(db).Model(model)
+
+	require.NoError(t, q.ensureTableSchema(context.Background()))
+
+	var sawCreate, sawAlter bool
+	for _, query := range drv.queries {
+		if strings.Contains(query, "CREATE TABLE IF NOT EXISTS") {
+			sawCreate = true
+		}
+		if strings.Contains(query, "ADD COLUMN IF NOT EXISTS") {
+			sawAlter = true
+		}
+	}
+	assert.True(t, sawCreate)
+	assert.True(t, sawAlter, "Postgres should get the minimal per-column ALTER")
+}
+
+func TestEnsureSchemaAddsOnlyMissingColumnsOnMySQL(t *testing.T) {
+	sqldb, drv := newFakeDB(t)
+	db := NewDB(sqldb, mysqldialect.New())
+
+	// The table already exists with "id" but not "name".
+	drv.queryRows = func(query string) *fakeRows {
+		return &fakeRows{columns: []string{"id"}}
+	}
+
+	model := &insertTestModel{Name: "a"}
+	q := NewInsertQuery(db).Model(model)
+
+	require.NoError(t, q.ensureTableSchema(context.Background()))
+
+	var sawCreate bool
+	var alters []string
+	for _, query := range drv.queries {
+		if strings.Contains(query, "CREATE TABLE IF NOT EXISTS") {
+			sawCreate = true
+		}
+		if strings.Contains(query, "ALTER TABLE") {
+			alters = append(alters, query)
+		}
+	}
+	assert.True(t, sawCreate)
+	require.Len(t, alters, 1, "only the missing column should be altered in")
+	assert.Contains(t, alters[0], "ADD COLUMN `name`")
+	assert.NotContains(t, alters[0], "IF NOT EXISTS", "MySQL ADD COLUMN here must not rely on IF NOT EXISTS")
+}